@@ -0,0 +1,142 @@
+package flagutil
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MapSetter is implemented by flag values, such as StringMapValue, that
+// can be populated from a decoded map[string]string without going
+// through their textual Set syntax.
+type MapSetter interface {
+	SetMap(map[string]string) error
+}
+
+// ListSetter is implemented by flag values, such as StringListValue,
+// that can be populated from a decoded []string without going through
+// their textual Set syntax.
+type ListSetter interface {
+	SetSlice([]string) error
+}
+
+// FileDecoder turns the raw contents of a fallback file into calls
+// against value, for file formats (JSON, YAML, ...) that don't use
+// flagutil's own escaped comma/colon syntax.
+type FileDecoder func(data []byte, value flag.Value) error
+
+// fileDecoders ships with JSON only: this checkout carries no
+// go.mod/go.sum, so a YAML decoder here would depend on a package that
+// can't be resolved. Call RegisterFileDecoder for ".yaml"/".yml" in a
+// tree that already vendors a YAML library.
+var fileDecoders = map[string]FileDecoder{
+	".json": decodeJSONFile,
+}
+
+// RegisterFileDecoder registers a FileDecoder for files whose name ends
+// in ext (including the leading '.', e.g. ".json"). It is intended to
+// be called from package init funcs.
+func RegisterFileDecoder(ext string, decoder FileDecoder) {
+	fileDecoders[ext] = decoder
+}
+
+func decodeJSONFile(data []byte, value flag.Value) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("flagutil: decoding JSON: %w", err)
+	}
+	return setMap(value, m)
+}
+
+func setMap(value flag.Value, m map[string]string) error {
+	setter, ok := value.(MapSetter)
+	if !ok {
+		return fmt.Errorf("flagutil: %T does not support loading from a decoded map", value)
+	}
+	return setter.SetMap(m)
+}
+
+// fallbackValue is the common implementation shared by EnvFallback and
+// FileFallback: it forwards to the wrapped flag.Value and remembers
+// whether Set was ever called on the command line, so Resolve knows
+// whether the fallback should apply.
+type fallbackValue struct {
+	flag.Value
+	wasSet bool
+}
+
+func (f *fallbackValue) Set(v string) error {
+	if err := f.Value.Set(v); err != nil {
+		return err
+	}
+	f.wasSet = true
+	return nil
+}
+
+// EnvFallbackValue wraps a flag.Value so that, if the flag was never
+// set on the command line, Resolve reads its value from an environment
+// variable using the same syntax Set would otherwise accept.
+type EnvFallbackValue struct {
+	fallbackValue
+	name string
+}
+
+// EnvFallback wraps value so that calling Resolve after flag.Parse
+// falls back to the environment variable name if the flag was never
+// set explicitly.
+func EnvFallback(name string, value flag.Value) *EnvFallbackValue {
+	return &EnvFallbackValue{fallbackValue: fallbackValue{Value: value}, name: name}
+}
+
+// Resolve applies the environment variable fallback if the flag was
+// never set explicitly and the variable is present in the environment.
+func (e *EnvFallbackValue) Resolve() error {
+	if e.wasSet {
+		return nil
+	}
+	v, ok := os.LookupEnv(e.name)
+	if !ok {
+		return nil
+	}
+	return e.Value.Set(v)
+}
+
+// FileFallbackValue wraps a flag.Value so that, if the flag was never
+// set on the command line, Resolve reads its value from a file.
+type FileFallbackValue struct {
+	fallbackValue
+	path string
+}
+
+// FileFallback wraps value so that calling Resolve after flag.Parse
+// falls back to the contents of path if the flag was never set
+// explicitly. The file's contents are parsed with value.Set, using the
+// same escaped comma/colon syntax as the command line, unless path's
+// extension matches a registered FileDecoder (see RegisterFileDecoder),
+// in which case that decoder is used instead — e.g. a StringMapValue
+// can be loaded from a "config.json" file containing {"key":"val"}.
+func FileFallback(path string, value flag.Value) *FileFallbackValue {
+	return &FileFallbackValue{fallbackValue: fallbackValue{Value: value}, path: path}
+}
+
+// Resolve applies the file fallback if the flag was never set
+// explicitly and the file exists.
+func (f *FileFallbackValue) Resolve() error {
+	if f.wasSet {
+		return nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("flagutil: reading fallback file %q: %w", f.path, err)
+	}
+	if decode, ok := fileDecoders[strings.ToLower(filepath.Ext(f.path))]; ok {
+		return decode(data, f.Value)
+	}
+	return f.Value.Set(strings.TrimSpace(string(data)))
+}
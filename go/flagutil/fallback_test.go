@@ -0,0 +1,110 @@
+package flagutil
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvFallbackAppliesWhenFlagNotSet(t *testing.T) {
+	t.Setenv("FLAGUTIL_TEST_ENV", "a,b")
+	var list StringListValue
+	f := EnvFallback("FLAGUTIL_TEST_ENV", &list)
+	if err := f.Resolve(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := list.String(), "a,b"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestEnvFallbackSkippedWhenFlagSet(t *testing.T) {
+	t.Setenv("FLAGUTIL_TEST_ENV", "a,b")
+	var list StringListValue
+	f := EnvFallback("FLAGUTIL_TEST_ENV", &list)
+	if err := f.Set("c,d"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.Resolve(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := list.String(), "c,d"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestEnvFallbackNoopWhenVarAbsent(t *testing.T) {
+	var list StringListValue
+	f := EnvFallback("FLAGUTIL_TEST_ENV_ABSENT", &list)
+	if err := f.Resolve(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := list.String(), ""; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFileFallbackAppliesWhenFlagNotSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist.txt")
+	writeFile(t, path, "a,b\n")
+
+	var list StringListValue
+	f := FileFallback(path, &list)
+	if err := f.Resolve(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := list.String(), "a,b"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFileFallbackNoopWhenFileMissing(t *testing.T) {
+	var list StringListValue
+	f := FileFallback(filepath.Join(t.TempDir(), "missing.txt"), &list)
+	if err := f.Resolve(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := list.String(), ""; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFileFallbackDecodesJSONByExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"key":"val"}`)
+
+	var m StringMapValue
+	f := FileFallback(path, &m)
+	if err := f.Resolve(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := m["key"], "val"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRegisterFileDecoderIsUsedByFileFallback(t *testing.T) {
+	RegisterFileDecoder(".flagutiltest", func(data []byte, value flag.Value) error {
+		return setMap(value, map[string]string{"decoded": string(data)})
+	})
+
+	path := filepath.Join(t.TempDir(), "config.flagutiltest")
+	writeFile(t, path, "payload")
+
+	var m StringMapValue
+	f := FileFallback(path, &m)
+	if err := f.Resolve(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := m["decoded"], "payload"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing %q: %v", path, err)
+	}
+}
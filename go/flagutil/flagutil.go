@@ -4,86 +4,391 @@ package flagutil
 
 import (
 	_ "flag"
+	"fmt"
 	"sort"
 	"strings"
 )
 
-// StringListValue is a []string flag that accepts a comma separated
-// list of elements. To include an element containing a comma, quote
-// it with a backslash '\'.
-type StringListValue []string
-
-func (value StringListValue) Get() interface{} {
-	return []string(value)
-}
-
+// parseListWithEscapes splits v on unescaped occurrences of delimiter.
+// Only "\\" and "\"+string(delimiter) are recognized escapes; any other
+// backslash is left untouched, so escaping for one delimiter doesn't
+// disturb escapes meant for a different delimiter when a value is
+// split more than once (e.g. first on a pair separator, then on a
+// key/value separator).
 func parseListWithEscapes(v string, delimiter rune) (value []string) {
-	var escaped, lastWasDelimiter bool
+	if v == "" {
+		return nil
+	}
+	runes := []rune(v)
 	var current []rune
-
-	for _, r := range v {
-		lastWasDelimiter = false
-		if !escaped {
-			switch r {
-			case delimiter:
-				value = append(value, string(current))
-				current = nil
-				lastWasDelimiter = true
-				continue
-			case '\\':
-				escaped = true
-				continue
-			}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\\' && i+1 < len(runes) && (runes[i+1] == delimiter || runes[i+1] == '\\') {
+			current = append(current, runes[i+1])
+			i++
+			continue
+		}
+		if r == delimiter {
+			value = append(value, string(current))
+			current = nil
+			continue
 		}
-		escaped = false
 		current = append(current, r)
 	}
-	if len(current) != 0 || lastWasDelimiter {
-		value = append(value, string(current))
-	}
+	value = append(value, string(current))
 	return value
 }
 
+func escapeWithDelimiter(v string, delimiters ...rune) string {
+	v = strings.Replace(v, `\`, `\\`, -1)
+	for _, delimiter := range delimiters {
+		v = strings.Replace(v, string(delimiter), `\`+string(delimiter), -1)
+	}
+	return v
+}
+
+// StringListValue is a []string flag that accepts a comma separated
+// list of elements. To include an element containing a comma, quote
+// it with a backslash '\'. Each occurrence of the flag on the command
+// line replaces the previous value. Use NewStringListValue to build a
+// flag.Value with a custom separator and/or that accumulates elements
+// across repeated occurrences instead.
+type StringListValue []string
+
+func (value StringListValue) Get() interface{} {
+	return []string(value)
+}
+
 func (value *StringListValue) Set(v string) error {
 	*value = parseListWithEscapes(v, ',')
 	return nil
 }
 
+// SetSlice assigns elements directly, without going through the
+// textual escape syntax. It is the entry point callers with
+// pre-parsed elements (e.g. a decoded JSON array) use instead of
+// round-tripping through Set's string form.
+func (value *StringListValue) SetSlice(elements []string) error {
+	*value = elements
+	return nil
+}
+
 func (value StringListValue) String() string {
 	parts := make([]string, len(value))
 	for i, v := range value {
-		parts[i] = strings.Replace(strings.Replace(v, "\\", "\\\\", -1), ",", `\,`, -1)
+		parts[i] = escapeWithDelimiter(v, ',')
 	}
 	return strings.Join(parts, ",")
+}
 
+// ConfiguredStringListValue is a flag.Value over a StringListValue that
+// supports a separator other than ',' and, in append mode, accumulates
+// elements across repeated occurrences of the flag instead of
+// replacing them on each occurrence, e.g. "--hosts a,b --hosts c,d"
+// producing [a b c d]. Use NewStringListValue to build one; the
+// underlying StringListValue, and therefore its JSON/slice
+// representation, is unaffected by this configuration.
+type ConfiguredStringListValue struct {
+	values *StringListValue
+	sep    rune
+	append bool
 }
 
+// NewStringListValue returns a ConfiguredStringListValue that splits on
+// sep (instead of the default ','), and that, if appendMode is true,
+// accumulates elements across repeated Set calls instead of replacing
+// them.
+func NewStringListValue(sep rune, appendMode bool) *ConfiguredStringListValue {
+	return &ConfiguredStringListValue{values: new(StringListValue), sep: sep, append: appendMode}
+}
+
+func (value *ConfiguredStringListValue) separator() rune {
+	if value.sep == 0 {
+		return ','
+	}
+	return value.sep
+}
+
+func (value *ConfiguredStringListValue) Get() interface{} {
+	return value.values.Get()
+}
+
+// computeSet parses v and returns what the new contents of *value.values
+// would be, without modifying it. Set and ListValue.Set both use this so
+// that a parse failure never leaves *value.values partially updated.
+func (value *ConfiguredStringListValue) computeSet(v string) StringListValue {
+	parsed := parseListWithEscapes(v, value.separator())
+	if !value.append {
+		return parsed
+	}
+	result := make(StringListValue, 0, len(*value.values)+len(parsed))
+	result = append(result, *value.values...)
+	result = append(result, parsed...)
+	return result
+}
+
+func (value *ConfiguredStringListValue) Set(v string) error {
+	*value.values = value.computeSet(v)
+	return nil
+}
+
+// SetSlice assigns elements directly, applying the same append-vs-replace
+// behavior as Set, without going through the textual escape syntax.
+func (value *ConfiguredStringListValue) SetSlice(elements []string) error {
+	if value.append {
+		*value.values = append(*value.values, elements...)
+	} else {
+		*value.values = elements
+	}
+	return nil
+}
+
+func (value *ConfiguredStringListValue) String() string {
+	sep := value.separator()
+	parts := make([]string, len(*value.values))
+	for i, v := range *value.values {
+		parts[i] = escapeWithDelimiter(v, sep)
+	}
+	return strings.Join(parts, string(sep))
+}
+
+// DupPolicy controls what a configured StringMapValue does when the
+// same key is set more than once, whether within a single Set call
+// (e.g. "a:1,a:2") or, for one built to accumulate, across repeated
+// flag occurrences.
+type DupPolicy int
+
+const (
+	// PolicyReplace keeps the last value seen for a duplicate key.
+	// This is the original, zero-value behavior.
+	PolicyReplace DupPolicy = iota
+	// PolicyError rejects the flag with a descriptive error if a key
+	// is set more than once.
+	PolicyError
+	// PolicyAppend concatenates values for a duplicate key, joined with
+	// ','. None of the constructors sets a different separator.
+	PolicyAppend
+)
+
 // StringMapValue is a map[string]string flag. It accepts a
-// comma-separated list of key value pairs, of the form key:value. The
-// keys cannot contain colons.
+// comma-separated list of key value pairs, of the form key:value. To
+// include a colon or a comma in a key or value, quote it with a
+// backslash '\'. Each occurrence of the flag on the
+// command line replaces the previous value, and a duplicate key keeps
+// its last value. Use NewStringMapValue to build a flag.Value with
+// custom separators, accumulation across repeated occurrences, and
+// control over what happens when a key is set more than once.
 type StringMapValue map[string]string
 
 func (value *StringMapValue) Set(v string) error {
 	dict := make(map[string]string)
 	pairs := parseListWithEscapes(v, ',')
-	for _, pair := range pairs {
-		parts := strings.SplitN(pair, ":", 2)
+	for i, pair := range pairs {
+		parts := parseListWithEscapes(pair, ':')
+		if len(parts) != 2 {
+			return fmt.Errorf("flagutil: invalid key:value pair %q at position %d, expected exactly one unescaped \":\" separator", pair, i)
+		}
 		dict[parts[0]] = parts[1]
 	}
 	*value = dict
 	return nil
 }
 
+// SetMap assigns m directly, without going through the textual escape
+// syntax. It is the entry point callers with a pre-decoded map (e.g.
+// from a JSON file) use instead of round-tripping through Set's string
+// form.
+func (value *StringMapValue) SetMap(m map[string]string) error {
+	*value = m
+	return nil
+}
+
 func (value StringMapValue) Get() interface{} {
 	return map[string]string(value)
 }
 
 func (value StringMapValue) String() string {
-	parts := make([]string, 0)
+	parts := make([]string, 0, len(value))
 	for k, v := range value {
-		parts = append(parts, k+":"+strings.Replace(v, ",", `\,`, -1))
+		parts = append(parts, escapeWithDelimiter(k, ':', ',')+":"+escapeWithDelimiter(v, ':', ','))
 	}
 	// Generate the string deterministically.
 	sort.Strings(parts)
 	return strings.Join(parts, ",")
 }
+
+// ConfiguredStringMapValue is a flag.Value over a StringMapValue that
+// supports separators other than the default ',' and ':', accumulation
+// of pairs across repeated occurrences of the flag, and a DupPolicy
+// controlling what happens when a key is set more than once. Use
+// NewStringMapValue, NewStringMapValueWithSeparators, or
+// NewStrictStringMapValue to build one; the underlying StringMapValue,
+// and therefore its JSON/map representation, is unaffected by this
+// configuration.
+type ConfiguredStringMapValue struct {
+	values     *StringMapValue
+	pairSep    rune
+	kvSep      rune
+	policy     DupPolicy
+	accumulate bool
+	appendSep  rune
+	strict     bool
+}
+
+// NewStringMapValue returns a ConfiguredStringMapValue that splits
+// pairs on pairSep (instead of the default ',') and keys from values on
+// kvSep (instead of the default ':'), accumulates pairs across repeated
+// Set calls, and applies policy when a key is set more than once.
+func NewStringMapValue(pairSep, kvSep rune, policy DupPolicy) *ConfiguredStringMapValue {
+	return &ConfiguredStringMapValue{values: new(StringMapValue), pairSep: pairSep, kvSep: kvSep, policy: policy, accumulate: true}
+}
+
+// NewStringMapValueWithSeparators returns a ConfiguredStringMapValue
+// that splits pairs on pairSep and keys from values on kvSep, e.g. '='
+// for "key=value,key2=value2", instead of the default ',' and ':'.
+func NewStringMapValueWithSeparators(pairSep, kvSep rune) *ConfiguredStringMapValue {
+	return &ConfiguredStringMapValue{values: new(StringMapValue), pairSep: pairSep, kvSep: kvSep}
+}
+
+// NewStrictStringMapValue returns a ConfiguredStringMapValue that
+// splits pairs on pairSep and keys from values on kvSep (instead of the
+// defaults ',' and ':'), accumulates pairs across repeated Set calls,
+// and, in addition to the guarantees of PolicyError, rejects empty keys
+// and whitespace-only keys.
+func NewStrictStringMapValue(pairSep, kvSep rune) *ConfiguredStringMapValue {
+	return &ConfiguredStringMapValue{values: new(StringMapValue), pairSep: pairSep, kvSep: kvSep, policy: PolicyError, accumulate: true, strict: true}
+}
+
+func (value *ConfiguredStringMapValue) pairSeparator() rune {
+	if value.pairSep == 0 {
+		return ','
+	}
+	return value.pairSep
+}
+
+func (value *ConfiguredStringMapValue) kvSeparator() rune {
+	if value.kvSep == 0 {
+		return ':'
+	}
+	return value.kvSep
+}
+
+func (value *ConfiguredStringMapValue) appendSeparator() rune {
+	if value.appendSep == 0 {
+		return ','
+	}
+	return value.appendSep
+}
+
+func (value *ConfiguredStringMapValue) putWithPolicy(dict map[string]string, key, v string) error {
+	if value.strict {
+		if key == "" {
+			return fmt.Errorf("flagutil: empty key is not allowed in strict mode")
+		}
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("flagutil: whitespace-only key %q is not allowed in strict mode", key)
+		}
+	}
+	if existing, ok := dict[key]; ok {
+		switch {
+		case value.strict || value.policy == PolicyError:
+			return fmt.Errorf("flagutil: duplicate key %q", key)
+		case value.policy == PolicyAppend:
+			dict[key] = existing + string(value.appendSeparator()) + v
+			return nil
+		}
+	}
+	dict[key] = v
+	return nil
+}
+
+// stripBrackets removes a single enclosing "[...]" pair, if present, so
+// that a bracketed serialized form (e.g. "[a:1,b:2]") parses the same
+// as the bare "a:1,b:2".
+func stripBrackets(v string) string {
+	if len(v) >= 2 && v[0] == '[' && v[len(v)-1] == ']' {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+func (value *ConfiguredStringMapValue) seedDict() map[string]string {
+	dict := make(map[string]string, len(*value.values))
+	if value.accumulate {
+		for k, v := range *value.values {
+			dict[k] = v
+		}
+	}
+	return dict
+}
+
+// computeSet parses v and returns what the new contents of
+// *value.values would be, without modifying it. Set and MapValue.Set
+// both use this so that a parse or policy failure never leaves
+// *value.values partially updated with some, but not all, of the pairs
+// from the same call.
+func (value *ConfiguredStringMapValue) computeSet(v string) (map[string]string, error) {
+	dict := value.seedDict()
+	pairs := parseListWithEscapes(stripBrackets(v), value.pairSeparator())
+	for i, pair := range pairs {
+		parts := parseListWithEscapes(pair, value.kvSeparator())
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("flagutil: invalid key%[1]svalue pair %[2]q at position %[3]d, expected exactly one unescaped %[1]q separator", string(value.kvSeparator()), pair, i)
+		}
+		if err := value.putWithPolicy(dict, parts[0], parts[1]); err != nil {
+			return nil, fmt.Errorf("%w (at position %d)", err, i)
+		}
+	}
+	return dict, nil
+}
+
+func (value *ConfiguredStringMapValue) Set(v string) error {
+	dict, err := value.computeSet(v)
+	if err != nil {
+		return err
+	}
+	*value.values = dict
+	return nil
+}
+
+// computeSetMap merges m into a copy of *value.values and returns the
+// result, without modifying it, applying the same accumulate and
+// duplicate-key policy behavior as Set.
+func (value *ConfiguredStringMapValue) computeSetMap(m map[string]string) (map[string]string, error) {
+	dict := value.seedDict()
+	for k, v := range m {
+		if err := value.putWithPolicy(dict, k, v); err != nil {
+			return nil, err
+		}
+	}
+	return dict, nil
+}
+
+// SetMap merges m into the value, applying the same accumulate and
+// duplicate-key policy behavior as Set, without going through the
+// textual escape syntax. It is the entry point callers with a
+// pre-decoded map (e.g. from a JSON file) use instead of round-tripping
+// through Set's string form.
+func (value *ConfiguredStringMapValue) SetMap(m map[string]string) error {
+	dict, err := value.computeSetMap(m)
+	if err != nil {
+		return err
+	}
+	*value.values = dict
+	return nil
+}
+
+func (value *ConfiguredStringMapValue) Get() interface{} {
+	return value.values.Get()
+}
+
+func (value *ConfiguredStringMapValue) String() string {
+	kvSep, pairSep := value.kvSeparator(), value.pairSeparator()
+	parts := make([]string, 0, len(*value.values))
+	for k, v := range *value.values {
+		parts = append(parts, escapeWithDelimiter(k, kvSep, pairSep)+string(kvSep)+escapeWithDelimiter(v, kvSep, pairSep))
+	}
+	// Generate the string deterministically.
+	sort.Strings(parts)
+	return strings.Join(parts, string(pairSep))
+}
@@ -0,0 +1,179 @@
+package flagutil
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestStringMapValueSetMissingSeparator(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var m StringMapValue
+	fs.Var(&m, "map", "")
+
+	if err := fs.Parse([]string{"--map", "foo"}); err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
+func TestStringMapValueSetKeyWithoutValue(t *testing.T) {
+	var m StringMapValue
+	err := m.Set("foo")
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
+func TestStrictStringMapValueRejectsEmptyKey(t *testing.T) {
+	m := NewStrictStringMapValue(',', ':')
+	if err := m.Set(":value"); err == nil {
+		t.Fatal("expected an error for an empty key, got nil")
+	}
+}
+
+func TestStrictStringMapValueRejectsWhitespaceKey(t *testing.T) {
+	m := NewStrictStringMapValue(',', ':')
+	if err := m.Set("  :value"); err == nil {
+		t.Fatal("expected an error for a whitespace-only key, got nil")
+	}
+}
+
+func TestStrictStringMapValueRejectsDuplicateKey(t *testing.T) {
+	m := NewStrictStringMapValue(',', ':')
+	if err := m.Set("a:1,a:2"); err == nil {
+		t.Fatal("expected an error for a duplicate key, got nil")
+	}
+}
+
+func TestStrictStringMapValueAllowsDistinctKeys(t *testing.T) {
+	m := NewStrictStringMapValue(',', ':')
+	if err := m.Set("a:1,b:2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := m.String(); got != "a:1,b:2" {
+		t.Fatalf("got %q, want %q", got, "a:1,b:2")
+	}
+}
+
+func TestStringMapValuePolicyReplaceIsDefault(t *testing.T) {
+	var m StringMapValue
+	if err := m.Set("a:1,a:2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := m.String(); got != "a:2" {
+		t.Fatalf("got %q, want %q", got, "a:2")
+	}
+}
+
+func TestStringListValueReplacesByDefault(t *testing.T) {
+	var l StringListValue
+	if err := l.Set("a,b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := l.Set("c,d"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := l.String(), "c,d"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestStringListValueAccumulatesAcrossSetCalls(t *testing.T) {
+	l := NewStringListValue(',', true)
+	if err := l.Set("a,b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := l.Set("c,d"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := l.String(), "a,b,c,d"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestStringMapValueAccumulatesAcrossSetCalls(t *testing.T) {
+	m := NewStringMapValue(',', ':', PolicyReplace)
+	if err := m.Set("a:1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Set("b:2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := m.String(), "a:1,b:2"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestStringMapValuePolicyErrorRejectsDuplicateKey(t *testing.T) {
+	m := NewStringMapValue(',', ':', PolicyError)
+	if err := m.Set("a:1,a:2"); err == nil {
+		t.Fatal("expected an error for a duplicate key, got nil")
+	}
+}
+
+func TestStringMapValuePolicyAppendConcatenatesValues(t *testing.T) {
+	m := NewStringMapValue(',', ':', PolicyAppend)
+	if err := m.Set("a:1,a:2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := (*m.values)["a"], "1,2"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestStringMapValueStringEscapesKeyContainingPairSeparator(t *testing.T) {
+	m := NewStringMapValueWithSeparators('=', ':')
+	if err := m.SetMap(map[string]string{"a=b": "1", "c": "2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := m.String()
+
+	roundTripped := NewStringMapValueWithSeparators('=', ':')
+	if err := roundTripped.Set(s); err != nil {
+		t.Fatalf("String() produced %q, which failed to round-trip through Set: %v", s, err)
+	}
+	if got, want := (*roundTripped.values)["a=b"], "1"; got != want {
+		t.Fatalf("round-tripped value for key %q: got %q, want %q", "a=b", got, want)
+	}
+	if got, want := (*roundTripped.values)["c"], "2"; got != want {
+		t.Fatalf("round-tripped value for key %q: got %q, want %q", "c", got, want)
+	}
+}
+
+func TestConfiguredStringMapValueSetDoesNotLeakPartialUpdateOnError(t *testing.T) {
+	m := NewStringMapValue(',', ':', PolicyError)
+	if err := m.Set("a:1,b:2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Set("c:3,b:99"); err == nil {
+		t.Fatal("expected an error for a duplicate key, got nil")
+	}
+	if err := m.Set("d:4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"a": "1", "b": "2", "d": "4"}
+	got := map[string]string(*m.values)
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestConfiguredStringMapValueSetMapDoesNotLeakPartialUpdateOnError(t *testing.T) {
+	m := NewStringMapValue(',', ':', PolicyError)
+	if err := m.SetMap(map[string]string{"a": "1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.SetMap(map[string]string{"b": "2", "a": "99"}); err == nil {
+		t.Fatal("expected an error for a duplicate key, got nil")
+	}
+	if got, want := (*m.values)["a"], "1"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if _, ok := (*m.values)["b"]; ok {
+		t.Fatalf("got %v, want key %q absent after a failed SetMap", *m.values, "b")
+	}
+}
@@ -0,0 +1,161 @@
+package flagutil
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ListValue is a generic flag.Value for a list of T that reuses
+// ConfiguredStringListValue's comma-separated, backslash-escaped syntax
+// and append-vs-replace semantics, parsing each element with parse.
+// This lets typed list flags (IntListValue, DurationListValue, ...)
+// share one implementation instead of hand-rolling a parser per type.
+type ListValue[T any] struct {
+	raw    *ConfiguredStringListValue
+	values []T
+	parse  func(string) (T, error)
+}
+
+// NewListValue returns a ListValue that splits on sep (instead of the
+// default ','), accumulates across repeated Set calls when appendMode
+// is true, and parses each element with parse.
+func NewListValue[T any](sep rune, appendMode bool, parse func(string) (T, error)) *ListValue[T] {
+	return &ListValue[T]{raw: NewStringListValue(sep, appendMode), parse: parse}
+}
+
+// Set parses and typed-validates every element of the candidate list
+// (the existing elements plus v's, in append mode) before committing
+// anything, so that an unparsable element never leaves the raw or
+// typed state partially updated.
+func (value *ListValue[T]) Set(v string) error {
+	candidate := value.raw.computeSet(v)
+	elems := make([]T, len(candidate))
+	for i, s := range candidate {
+		parsed, err := value.parse(s)
+		if err != nil {
+			return fmt.Errorf("flagutil: parsing element %d (%q): %w", i, s, err)
+		}
+		elems[i] = parsed
+	}
+	*value.raw.values = candidate
+	value.values = elems
+	return nil
+}
+
+func (value *ListValue[T]) Get() interface{} {
+	return value.values
+}
+
+func (value *ListValue[T]) String() string {
+	return value.raw.String()
+}
+
+// MapValue is a generic flag.Value for a map of K to V that reuses
+// ConfiguredStringMapValue's syntax, separators, and duplicate-key
+// policy, parsing each key and value with parseKey and parseVal. This
+// lets typed map flags (StringToIntValue, StringToDurationValue, ...)
+// share one implementation instead of hand-rolling a parser per type.
+type MapValue[K comparable, V any] struct {
+	raw      *ConfiguredStringMapValue
+	values   map[K]V
+	parseKey func(string) (K, error)
+	parseVal func(string) (V, error)
+}
+
+// NewMapValue returns a MapValue that splits pairs on pairSep and keys
+// from values on kvSep (instead of the defaults ',' and ':'),
+// accumulates pairs across repeated Set calls, applies policy when a
+// key is set more than once, and parses each key/value with parseKey
+// and parseVal.
+func NewMapValue[K comparable, V any](pairSep, kvSep rune, policy DupPolicy, parseKey func(string) (K, error), parseVal func(string) (V, error)) *MapValue[K, V] {
+	return &MapValue[K, V]{
+		raw:      NewStringMapValue(pairSep, kvSep, policy),
+		parseKey: parseKey,
+		parseVal: parseVal,
+	}
+}
+
+// Set parses the candidate pairs (the existing pairs plus v's, subject
+// to the duplicate-key policy) and typed-validates every key and value
+// before committing anything, so that an unparsable key/value or a
+// policy violation never leaves the raw or typed state partially
+// updated.
+func (value *MapValue[K, V]) Set(v string) error {
+	dict, err := value.raw.computeSet(v)
+	if err != nil {
+		return err
+	}
+	values := make(map[K]V, len(dict))
+	for k, v := range dict {
+		parsedKey, err := value.parseKey(k)
+		if err != nil {
+			return fmt.Errorf("flagutil: parsing key %q: %w", k, err)
+		}
+		parsedVal, err := value.parseVal(v)
+		if err != nil {
+			return fmt.Errorf("flagutil: parsing value for key %q: %w", k, err)
+		}
+		values[parsedKey] = parsedVal
+	}
+	*value.raw.values = dict
+	value.values = values
+	return nil
+}
+
+func (value *MapValue[K, V]) Get() interface{} {
+	return value.values
+}
+
+func (value *MapValue[K, V]) String() string {
+	return value.raw.String()
+}
+
+func identity(s string) (string, error) { return s, nil }
+
+// IntListValue is a []int flag using ListValue's comma-separated,
+// backslash-escaped syntax.
+type IntListValue = ListValue[int]
+
+// NewIntListValue returns an IntListValue that splits on sep (instead
+// of the default ',') and, if appendMode is true, accumulates elements
+// across repeated Set calls instead of replacing them.
+func NewIntListValue(sep rune, appendMode bool) *IntListValue {
+	return NewListValue(sep, appendMode, func(s string) (int, error) {
+		return strconv.Atoi(s)
+	})
+}
+
+// DurationListValue is a []time.Duration flag using ListValue's
+// comma-separated, backslash-escaped syntax.
+type DurationListValue = ListValue[time.Duration]
+
+// NewDurationListValue returns a DurationListValue that splits on sep
+// (instead of the default ',') and, if appendMode is true, accumulates
+// elements across repeated Set calls instead of replacing them.
+func NewDurationListValue(sep rune, appendMode bool) *DurationListValue {
+	return NewListValue(sep, appendMode, time.ParseDuration)
+}
+
+// StringToIntValue is a map[string]int flag using MapValue's syntax,
+// separators, and duplicate-key policy.
+type StringToIntValue = MapValue[string, int]
+
+// NewStringToIntValue returns a StringToIntValue that splits pairs on
+// pairSep and keys from values on kvSep (instead of the defaults ','
+// and ':'), and applies policy when a key is set more than once.
+func NewStringToIntValue(pairSep, kvSep rune, policy DupPolicy) *StringToIntValue {
+	return NewMapValue(pairSep, kvSep, policy, identity, strconv.Atoi)
+}
+
+// StringToDurationValue is a map[string]time.Duration flag using
+// MapValue's syntax, separators, and duplicate-key policy.
+type StringToDurationValue = MapValue[string, time.Duration]
+
+// NewStringToDurationValue returns a StringToDurationValue that splits
+// pairs on pairSep and keys from values on kvSep (instead of the
+// defaults ',' and ':'), and applies policy when a key is set more
+// than once.
+func NewStringToDurationValue(pairSep, kvSep rune, policy DupPolicy) *StringToDurationValue {
+	return NewMapValue(pairSep, kvSep, policy, identity, time.ParseDuration)
+}
@@ -0,0 +1,111 @@
+package flagutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntListValueParsesElements(t *testing.T) {
+	l := NewIntListValue(',', false)
+	if err := l.Set("1,2,3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := l.Get().([]int)
+	want := []int{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestIntListValueReportsBadElement(t *testing.T) {
+	l := NewIntListValue(',', false)
+	err := l.Set("1,x,3")
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
+func TestDurationListValueParsesElements(t *testing.T) {
+	l := NewDurationListValue(',', false)
+	if err := l.Set("1s,2m"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := l.Get().([]time.Duration)
+	want := []time.Duration{time.Second, 2 * time.Minute}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestStringToIntValueParsesPairs(t *testing.T) {
+	m := NewStringToIntValue(',', ':', PolicyReplace)
+	if err := m.Set("a:1,b:2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := m.Get().(map[string]int)
+	if got["a"] != 1 || got["b"] != 2 {
+		t.Fatalf("got %v, want map[a:1 b:2]", got)
+	}
+}
+
+func TestStringToIntValueSetDoesNotLeakPartialUpdateOnError(t *testing.T) {
+	m := NewStringToIntValue(',', ':', PolicyError)
+	if err := m.Set("a:1,b:2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Set("c:3,b:99"); err == nil {
+		t.Fatal("expected an error for a duplicate key, got nil")
+	}
+	if err := m.Set("d:4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := m.Get().(map[string]int)
+	want := map[string]int{"a": 1, "b": 2, "d": 4}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestIntListValueSetDoesNotLeakPartialUpdateOnError(t *testing.T) {
+	l := NewIntListValue(',', true)
+	if err := l.Set("1,2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := l.Set("x,3"); err == nil {
+		t.Fatal("expected an error for an unparsable element, got nil")
+	}
+	if err := l.Set("4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := l.Get().([]int)
+	want := []int{1, 2, 4}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestStringToDurationValueParsesPairs(t *testing.T) {
+	m := NewStringToDurationValue(',', ':', PolicyReplace)
+	if err := m.Set("a:1s,b:2m"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := m.Get().(map[string]time.Duration)
+	if got["a"] != time.Second || got["b"] != 2*time.Minute {
+		t.Fatalf("got %v, want map[a:1s b:2m]", got)
+	}
+}
@@ -0,0 +1,95 @@
+package flagutil
+
+import (
+	"sort"
+	"strings"
+)
+
+// StringSetValue is a flag.Value that parses the same comma-separated,
+// backslash-escaped syntax as StringListValue, but de-duplicates
+// elements instead of preserving order and repeats. It's useful for
+// flags like allowed tablet types or cell allow-lists, where a
+// duplicate entry today silently inflates a StringListValue without
+// changing its meaning.
+type StringSetValue struct {
+	values map[string]struct{}
+	sep    rune
+}
+
+// NewStringSetValue returns a StringSetValue that splits on sep
+// (instead of the default ',').
+func NewStringSetValue(sep rune) *StringSetValue {
+	return &StringSetValue{sep: sep}
+}
+
+func (value *StringSetValue) separator() rune {
+	if value.sep == 0 {
+		return ','
+	}
+	return value.sep
+}
+
+func (value *StringSetValue) Get() interface{} {
+	return value.Slice()
+}
+
+func (value *StringSetValue) Set(v string) error {
+	if value.values == nil {
+		value.values = make(map[string]struct{})
+	}
+	for _, s := range parseListWithEscapes(v, value.separator()) {
+		value.values[s] = struct{}{}
+	}
+	return nil
+}
+
+func (value *StringSetValue) String() string {
+	sep := value.separator()
+	parts := value.Slice()
+	for i, v := range parts {
+		parts[i] = escapeWithDelimiter(v, sep)
+	}
+	return strings.Join(parts, string(sep))
+}
+
+// Contains reports whether s is a member of the set.
+func (value *StringSetValue) Contains(s string) bool {
+	_, ok := value.values[s]
+	return ok
+}
+
+// Slice returns the set's elements in sorted order, for deterministic
+// output.
+func (value *StringSetValue) Slice() []string {
+	out := make([]string, 0, len(value.values))
+	for s := range value.values {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Union returns a new StringSetValue containing the elements of both
+// sets.
+func (value *StringSetValue) Union(other *StringSetValue) *StringSetValue {
+	result := &StringSetValue{values: make(map[string]struct{}), sep: value.sep}
+	for s := range value.values {
+		result.values[s] = struct{}{}
+	}
+	for s := range other.values {
+		result.values[s] = struct{}{}
+	}
+	return result
+}
+
+// Intersect returns a new StringSetValue containing only the elements
+// present in both sets.
+func (value *StringSetValue) Intersect(other *StringSetValue) *StringSetValue {
+	result := &StringSetValue{values: make(map[string]struct{}), sep: value.sep}
+	for s := range value.values {
+		if _, ok := other.values[s]; ok {
+			result.values[s] = struct{}{}
+		}
+	}
+	return result
+}
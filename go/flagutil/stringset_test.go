@@ -0,0 +1,74 @@
+package flagutil
+
+import "testing"
+
+func TestStringSetValueDeduplicates(t *testing.T) {
+	var s StringSetValue
+	if err := s.Set("a,b,a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := s.Slice(), []string{"a", "b"}; !equalSlices(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestStringSetValueContains(t *testing.T) {
+	var s StringSetValue
+	if err := s.Set("a,b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !s.Contains("a") {
+		t.Fatal("expected set to contain \"a\"")
+	}
+	if s.Contains("c") {
+		t.Fatal("expected set not to contain \"c\"")
+	}
+}
+
+func TestStringSetValueStringIsSorted(t *testing.T) {
+	var s StringSetValue
+	if err := s.Set("b,a,c"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := s.String(), "a,b,c"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestStringSetValueUnion(t *testing.T) {
+	var a, b StringSetValue
+	if err := a.Set("a,b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.Set("b,c"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := a.Union(&b).Slice(), []string{"a", "b", "c"}; !equalSlices(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestStringSetValueIntersect(t *testing.T) {
+	var a, b StringSetValue
+	if err := a.Set("a,b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.Set("b,c"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := a.Intersect(&b).Slice(), []string{"b"}; !equalSlices(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func equalSlices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}